@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2021 - 2023 Iván Szkiba
+//
+// SPDX-License-Identifier: MIT
+
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LabelRules controls how k6 tags are translated into Prometheus labels for
+// a metric. An empty LabelRules leaves the tags untouched.
+type LabelRules struct {
+	// Rename maps a k6 tag name to the Prometheus label name it should be
+	// exposed as.
+	Rename map[string]string `yaml:"rename,omitempty"`
+	// Drop removes the named tags before they become labels.
+	Drop []string `yaml:"drop,omitempty"`
+	// Whitelist, when non-empty, keeps only the named tags and drops
+	// everything else.
+	Whitelist []string `yaml:"whitelist,omitempty"`
+}
+
+func (r LabelRules) apply(tags map[string]string) map[string]string {
+	if len(r.Whitelist) == 0 && len(r.Drop) == 0 && len(r.Rename) == 0 {
+		return tags
+	}
+
+	out := make(map[string]string, len(tags))
+
+	for k, v := range tags {
+		if len(r.Whitelist) > 0 && !containsString(r.Whitelist, k) {
+			continue
+		}
+
+		out[k] = v
+	}
+
+	for _, k := range r.Drop {
+		delete(out, k)
+	}
+
+	for from, to := range r.Rename {
+		if v, ok := out[from]; ok {
+			delete(out, from)
+			out[to] = v
+		}
+	}
+
+	return out
+}
+
+// mergeLabelRules combines a global LabelRules with a per-metric override:
+// Drop lists are unioned, while a non-empty Rename or Whitelist on override
+// replaces the global one.
+func mergeLabelRules(global, override LabelRules) LabelRules {
+	merged := global
+
+	if len(override.Drop) > 0 {
+		merged.Drop = append(append([]string{}, global.Drop...), override.Drop...)
+	}
+
+	if len(override.Rename) > 0 {
+		merged.Rename = override.Rename
+	}
+
+	if len(override.Whitelist) > 0 {
+		merged.Whitelist = override.Whitelist
+	}
+
+	return merged
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MappingRule overrides how a k6 metric (or a glob of metrics) is exposed.
+type MappingRule struct {
+	// Match is a glob pattern, as accepted by path.Match, tested against
+	// sample.Metric.Name.
+	Match string `yaml:"match"`
+	// Name renames the metric. Leave empty to keep the original name.
+	Name string `yaml:"name,omitempty"`
+	// MatchMetricType forces how the metric is exposed, overriding the type
+	// PrometheusAdapter would otherwise derive from the sample: one of
+	// "counter", "gauge", "histogram" or "summary".
+	MatchMetricType string `yaml:"match_metric_type,omitempty"`
+	// Buckets sets the bucket boundaries used when the metric is exposed as
+	// a histogram.
+	Buckets []float64 `yaml:"buckets,omitempty"`
+	// Quantiles sets the objectives (quantile -> allowed error) used when
+	// the metric is exposed as a summary.
+	Quantiles map[float64]float64 `yaml:"quantiles,omitempty"`
+	// Labels controls how k6 tags become Prometheus labels for this metric.
+	Labels LabelRules `yaml:"labels,omitempty"`
+	// Help overrides the metric's HELP text.
+	Help string `yaml:"help,omitempty"`
+}
+
+// MappingConfig is the top level mapping file, loaded from YAML via
+// LoadMappingConfig.
+type MappingConfig struct {
+	Rules []MappingRule `yaml:"mappings"`
+}
+
+// LoadMappingConfig reads and parses a mapping file, e.g. the one pointed to
+// by the `mapping` output option (`output prometheus=mapping=./mapping.yaml`).
+func LoadMappingConfig(file string) (*MappingConfig, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read mapping config: %w", err)
+	}
+
+	var config MappingConfig
+
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parse mapping config: %w", err)
+	}
+
+	return &config, nil
+}
+
+// find returns the first rule whose Match pattern matches name, or nil if
+// config is nil or no rule matches.
+func (c *MappingConfig) find(name string) *MappingRule {
+	if c == nil {
+		return nil
+	}
+
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+
+		ok, err := path.Match(rule.Match, name)
+		if err != nil {
+			continue
+		}
+
+		if ok {
+			return rule
+		}
+	}
+
+	return nil
+}
@@ -7,6 +7,8 @@ package internal
 import (
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -18,8 +20,67 @@ type PrometheusAdapter struct {
 	Subsystem string
 	Namespace string
 	logger    logrus.FieldLogger
-	metrics   map[string]interface{}
 	registry  *prometheus.Registry
+	mapping   *MappingConfig
+
+	mu             sync.Mutex
+	metrics        map[string]interface{}
+	ttl            time.Duration
+	seriesByMetric map[string]map[string]*seriesInfo
+	totalSeries    uint64
+	done           chan struct{}
+	closeOnce      sync.Once
+
+	trendMode          TrendMode
+	nativeBucketFactor float64
+	nativeMaxBuckets   uint32
+
+	globalLabels       LabelRules
+	maxSeriesPerMetric uint64
+	maxTotalSeries     uint64
+	warnedCardinality  map[string]bool
+	droppedSeries      *prometheus.CounterVec
+}
+
+// TrendMode selects how k6 Trend metrics are exposed to Prometheus.
+type TrendMode int
+
+const (
+	// TrendSummary exposes a Trend as a classic Summary (the original behavior).
+	TrendSummary TrendMode = iota
+	// TrendHistogram exposes a Trend as a classic Histogram.
+	TrendHistogram
+	// TrendNativeHistogram exposes a Trend as a Prometheus native histogram.
+	TrendNativeHistogram
+)
+
+// ParseTrendMode parses the trend_as output option ("summary", "histogram"
+// or "native_histogram"). An empty string selects TrendNativeHistogram, the
+// adapter's default.
+func ParseTrendMode(s string) (TrendMode, bool) {
+	switch s {
+	case "summary":
+		return TrendSummary, true
+	case "histogram":
+		return TrendHistogram, true
+	case "", "native_histogram":
+		return TrendNativeHistogram, true
+	default:
+		return 0, false
+	}
+}
+
+// seriesInfo tracks when a single label-value combination was last observed,
+// so the TTL sweeper can expire it.
+type seriesInfo struct {
+	lastSeen time.Time
+	values   []string
+}
+
+// vecHandle is the subset of the generated *Vec types the TTL sweeper needs.
+type vecHandle interface {
+	prometheus.Collector
+	DeleteLabelValues(lvs ...string) bool
 }
 
 type labelNames []string
@@ -44,14 +105,221 @@ type histogramWithLabels struct {
 	labelNames   labelNames
 }
 
-func NewPrometheusAdapter(registry *prometheus.Registry, logger logrus.FieldLogger, ns, sub string) *PrometheusAdapter {
-	return &PrometheusAdapter{
-		Subsystem: sub,
-		Namespace: ns,
-		logger:    logger,
-		registry:  registry,
-		metrics:   make(map[string]interface{}),
+// NewPrometheusAdapter creates an adapter backed by registry. ttl, when
+// positive, starts a background sweeper that expires idle label series;
+// 0 disables expiration. Call Close to stop the sweeper.
+func NewPrometheusAdapter(registry *prometheus.Registry, logger logrus.FieldLogger, ns, sub string, ttl time.Duration) *PrometheusAdapter {
+	a := &PrometheusAdapter{
+		Subsystem:          sub,
+		Namespace:          ns,
+		logger:             logger,
+		registry:           registry,
+		mapping:            nil,
+		metrics:            make(map[string]interface{}),
+		ttl:                ttl,
+		seriesByMetric:     make(map[string]map[string]*seriesInfo),
+		totalSeries:        0,
+		done:               nil,
+		trendMode:          TrendNativeHistogram,
+		nativeBucketFactor: defaultNativeBucketFactor,
+		nativeMaxBuckets:   defaultNativeMaxBuckets,
+		globalLabels:       LabelRules{}, // nolint:exhaustruct
+		maxSeriesPerMetric: defaultMaxSeriesPerMetric,
+		maxTotalSeries:     0,
+		warnedCardinality:  make(map[string]bool),
+		droppedSeries: prometheus.NewCounterVec(prometheus.CounterOpts{ // nolint:exhaustruct
+			Namespace: "xk6",
+			Subsystem: "prometheus",
+			Name:      "dropped_series_total",
+			Help:      "Total number of new label combinations dropped because a cardinality limit was reached.",
+		}, []string{"metric"}),
+	}
+
+	if err := registry.Register(a.droppedSeries); err != nil {
+		logger.Error(err)
+	}
+
+	if ttl > 0 {
+		a.done = make(chan struct{})
+
+		go a.sweepLoop()
+	}
+
+	return a
+}
+
+const (
+	defaultNativeBucketFactor = 1.1
+	defaultNativeMaxBuckets   = 160
+	defaultMaxSeriesPerMetric = 10000
+)
+
+// SetGlobalLabelRules installs label filtering rules applied to every metric,
+// merged with any per-metric rule via mergeLabelRules.
+func (a *PrometheusAdapter) SetGlobalLabelRules(rules LabelRules) {
+	a.globalLabels = rules
+}
+
+// SetCardinalityLimits bounds how many distinct label combinations a single
+// metric or the adapter as a whole may register. 0 disables a limit.
+func (a *PrometheusAdapter) SetCardinalityLimits(maxSeriesPerMetric, maxTotalSeries uint64) {
+	a.maxSeriesPerMetric = maxSeriesPerMetric
+	a.maxTotalSeries = maxTotalSeries
+}
+
+// SetTrendMode selects how Trend metrics are exposed. bucketFactor and
+// maxBuckets only apply to TrendNativeHistogram; pass 0 for either to keep
+// the current value.
+func (a *PrometheusAdapter) SetTrendMode(mode TrendMode, bucketFactor float64, maxBuckets uint32) {
+	a.trendMode = mode
+
+	if bucketFactor > 0 {
+		a.nativeBucketFactor = bucketFactor
+	}
+
+	if maxBuckets > 0 {
+		a.nativeMaxBuckets = maxBuckets
+	}
+}
+
+// Close stops the TTL sweeper goroutine started by NewPrometheusAdapter, if
+// any. Safe to call when ttl was 0.
+func (a *PrometheusAdapter) Close() {
+	if a.done == nil {
+		return
+	}
+
+	a.closeOnce.Do(func() {
+		close(a.done)
+	})
+}
+
+// SetMapping installs a mapping config that handleSample consults before
+// falling back to the type-based routing below. A nil config (the default)
+// applies no mapping rule, so every metric keeps its name and type-based
+// routing.
+func (a *PrometheusAdapter) SetMapping(mapping *MappingConfig) {
+	a.mapping = mapping
+}
+
+func (a *PrometheusAdapter) sweepInterval() time.Duration {
+	const minInterval = time.Second
+
+	interval := a.ttl / 4
+	if interval < minInterval {
+		interval = minInterval
+	}
+
+	return interval
+}
+
+func (a *PrometheusAdapter) sweepLoop() {
+	ticker := time.NewTicker(a.sweepInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			a.sweep(now)
+		case <-a.done:
+			return
+		}
+	}
+}
+
+// sweep unregisters label series idle for longer than ttl, and the vec
+// itself once it has no series left.
+func (a *PrometheusAdapter) sweep(now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for name, series := range a.seriesByMetric {
+		vec, ok := a.vecFor(name)
+		if !ok {
+			continue
+		}
+
+		for key, info := range series {
+			if now.Sub(info.lastSeen) <= a.ttl {
+				continue
+			}
+
+			vec.DeleteLabelValues(info.values...)
+			delete(series, key)
+			a.totalSeries--
+		}
+
+		if len(series) == 0 {
+			a.registry.Unregister(vec)
+			delete(a.metrics, name)
+			delete(a.seriesByMetric, name)
+		}
+	}
+}
+
+func (a *PrometheusAdapter) vecFor(name string) (vecHandle, bool) {
+	switch m := a.metrics[name].(type) {
+	case *counterWithLabels:
+		return m.counterVec, true
+	case *gaugeWithLabels:
+		return m.gaugeVec, true
+	case *summaryWithLabels:
+		return m.summaryVec, true
+	case *histogramWithLabels:
+		return m.histogramVec, true
+	default:
+		return nil, false
+	}
+}
+
+// admit records labelValues as observed for metric name and reports whether
+// it's allowed under the configured cardinality limits. Existing series are
+// always admitted; only a new label combination can be rejected.
+func (a *PrometheusAdapter) admit(name string, labelValues []string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	series, ok := a.seriesByMetric[name]
+	if !ok {
+		series = make(map[string]*seriesInfo)
+		a.seriesByMetric[name] = series
+	}
+
+	key := strings.Join(labelValues, "\x1f")
+
+	if info, ok := series[key]; ok {
+		info.lastSeen = time.Now()
+
+		return true
+	}
+
+	if a.maxSeriesPerMetric > 0 && uint64(len(series)) >= a.maxSeriesPerMetric {
+		a.dropSeries(name, "max_series_per_metric")
+
+		return false
+	}
+
+	if a.maxTotalSeries > 0 && a.totalSeries >= a.maxTotalSeries {
+		a.dropSeries(name, "max_total_series")
+
+		return false
+	}
+
+	series[key] = &seriesInfo{lastSeen: time.Now(), values: append([]string(nil), labelValues...)}
+	a.totalSeries++
+
+	return true
+}
+
+// dropSeries records a cardinality-limit rejection: it logs once per metric
+// and increments xk6_prometheus_dropped_series_total. Called with a.mu held.
+func (a *PrometheusAdapter) dropSeries(name, reason string) {
+	if !a.warnedCardinality[name] {
+		a.warnedCardinality[name] = true
+		a.logger.Warnf("Cardinality limit (%s) reached for metric %q; dropping new label combinations", reason, name)
 	}
+
+	a.droppedSeries.WithLabelValues(name).Inc()
 }
 
 func (a *PrometheusAdapter) AddMetricSamples(samples []metrics.SampleContainer) {
@@ -64,33 +332,155 @@ func (a *PrometheusAdapter) AddMetricSamples(samples []metrics.SampleContainer)
 }
 
 func (a *PrometheusAdapter) Handler() http.Handler {
-	return promhttp.HandlerFor(a.registry, promhttp.HandlerOpts{}) // nolint:exhaustruct
+	// EnableOpenMetrics lets promhttp negotiate the OpenMetrics/protobuf
+	// exposition format a scraper asks for, which is required to expose
+	// native histograms (see TrendNativeHistogram).
+	return promhttp.HandlerFor(a.registry, promhttp.HandlerOpts{EnableOpenMetrics: true}) // nolint:exhaustruct
 }
 
-func (a *PrometheusAdapter) handleSample(sample *metrics.Sample) {
-	var handler func(*metrics.Sample)
+// promType is the Prometheus metric kind a sample is exposed as, which
+// usually follows from the k6 metric type but can be overridden by a
+// mapping rule's match_metric_type.
+type promType int
 
-	switch sample.Metric.Type {
+const (
+	promCounter promType = iota
+	promGauge
+	promHistogram
+	promSummary
+)
+
+func defaultPromType(t metrics.MetricType) (promType, bool) {
+	switch t {
 	case metrics.Counter:
-		handler = a.handleCounter
+		return promCounter, true
 	case metrics.Gauge:
-		handler = a.handleGauge
+		return promGauge, true
 	case metrics.Rate:
-		handler = a.handleRate
+		return promHistogram, true
 	case metrics.Trend:
-		handler = a.handleTrend
+		return promSummary, true
+	default:
+		return 0, false
+	}
+}
+
+func parsePromType(s string) (promType, bool) {
+	switch s {
+	case "counter":
+		return promCounter, true
+	case "gauge":
+		return promGauge, true
+	case "histogram":
+		return promHistogram, true
+	case "summary":
+		return promSummary, true
 	default:
+		return 0, false
+	}
+}
+
+// resolution is the effective exposition for one sample, after consulting
+// the mapping config (if any).
+type resolution struct {
+	name      string
+	help      string
+	promType  promType
+	native    bool // only meaningful when promType == promHistogram
+	buckets   []float64
+	quantiles map[float64]float64
+	labels    LabelRules
+}
+
+func (a *PrometheusAdapter) resolve(sample *metrics.Sample, fallback promType) resolution {
+	res := resolution{
+		name:      sample.Metric.Name,
+		help:      "",
+		promType:  fallback,
+		native:    false,
+		buckets:   defaultBuckets,
+		quantiles: nil,
+		labels:    a.globalLabels,
+	}
+
+	if sample.Metric.Type == metrics.Trend {
+		switch a.trendMode {
+		case TrendHistogram:
+			res.promType = promHistogram
+			res.buckets = defaultTrendBuckets
+		case TrendNativeHistogram:
+			res.promType = promHistogram
+			res.native = true
+			res.buckets = defaultTrendBuckets
+		case TrendSummary:
+			res.promType = promSummary
+		}
+	}
+
+	rule := a.mapping.find(sample.Metric.Name)
+	if rule == nil {
+		return res
+	}
+
+	if rule.Name != "" {
+		res.name = rule.Name
+	}
+
+	res.help = rule.Help
+	res.labels = mergeLabelRules(a.globalLabels, rule.Labels)
+	res.quantiles = rule.Quantiles
+
+	if len(rule.Buckets) > 0 {
+		res.buckets = rule.Buckets
+	}
+
+	if rule.MatchMetricType != "" {
+		if t, ok := parsePromType(rule.MatchMetricType); ok {
+			res.promType = t
+			res.native = false // an explicit mapping override always means a classic histogram
+
+			if t == promHistogram && sample.Metric.Type == metrics.Trend && len(rule.Buckets) == 0 {
+				res.buckets = defaultTrendBuckets
+			}
+		} else {
+			a.logger.Warnf("Unknown match_metric_type %q for metric %q", rule.MatchMetricType, sample.Metric.Name)
+		}
+	}
+
+	return res
+}
+
+func (a *PrometheusAdapter) handleSample(sample *metrics.Sample) {
+	fallback, ok := defaultPromType(sample.Metric.Type)
+	if !ok {
 		a.logger.Warnf("Unknown metric type: %v", sample.Metric.Type)
 
 		return
 	}
 
-	handler(sample)
+	res := a.resolve(sample, fallback)
+
+	switch res.promType {
+	case promCounter:
+		a.exposeCounter(sample, res)
+	case promGauge:
+		a.exposeGauge(sample, res)
+	case promHistogram:
+		a.exposeHistogram(sample, res)
+	case promSummary:
+		a.exposeSummary(sample, res)
+	}
+
+	if sample.Metric.Type == metrics.Trend {
+		a.exposeCurrent(sample, res)
+	}
 }
 
-func (a *PrometheusAdapter) tagsToLabelNames(tags *metrics.TagSet) []string {
-	m := tags.Map()
-	m["tls_version"] = "" // created later by k6
+func (a *PrometheusAdapter) tagsToLabelNames(tags *metrics.TagSet, labels LabelRules) []string {
+	raw := tags.Map()
+	raw["tls_version"] = "" // created later by k6
+
+	m := labels.apply(raw)
 
 	keys := make([]string, 0, len(m))
 
@@ -101,8 +491,8 @@ func (a *PrometheusAdapter) tagsToLabelNames(tags *metrics.TagSet) []string {
 	return keys
 }
 
-func (a *PrometheusAdapter) tagsToLabelValues(labelNames []string, sampleTags *metrics.TagSet) []string {
-	tags := sampleTags.Map()
+func (a *PrometheusAdapter) tagsToLabelValues(labelNames []string, sampleTags *metrics.TagSet, labels LabelRules) []string {
+	tags := labels.apply(sampleTags.Map())
 	labelValues := []string{}
 
 	for _, label := range labelNames {
@@ -117,80 +507,122 @@ func (a *PrometheusAdapter) tagsToLabelValues(labelNames []string, sampleTags *m
 	return labelValues
 }
 
-func (a *PrometheusAdapter) handleCounter(sample *metrics.Sample) {
-	if counter := a.getCounter(sample.Metric.Name, "k6 counter", sample.Tags); counter != nil {
-		labelValues := a.tagsToLabelValues(counter.labelNames, sample.Tags)
-		metric, err := counter.counterVec.GetMetricWithLabelValues(labelValues...)
+func (a *PrometheusAdapter) exposeCounter(sample *metrics.Sample, res resolution) {
+	counter := a.getCounter(res.name, "k6 counter", res.help, sample.Tags, res.labels)
+	if counter == nil {
+		return
+	}
 
-		if err != nil {
-			a.logger.Error(err)
-		} else {
-			metric.Add(sample.Value)
-		}
+	labelValues := a.tagsToLabelValues(counter.labelNames, sample.Tags, res.labels)
+	if !a.admit(res.name, labelValues) {
+		return
+	}
+
+	metric, err := counter.counterVec.GetMetricWithLabelValues(labelValues...)
+	if err != nil {
+		a.logger.Error(err)
+	} else {
+		metric.Add(sample.Value)
 	}
 }
 
-func (a *PrometheusAdapter) handleGauge(sample *metrics.Sample) {
-	if gauge := a.getGauge(sample.Metric.Name, "k6 gauge", sample.Tags); gauge != nil {
-		labelValues := a.tagsToLabelValues(gauge.labelNames, sample.Tags)
-		metric, err := gauge.gaugeVec.GetMetricWithLabelValues(labelValues...)
+func (a *PrometheusAdapter) exposeGauge(sample *metrics.Sample, res resolution) {
+	gauge := a.getGauge(res.name, "k6 gauge", res.help, sample.Tags, res.labels)
+	if gauge == nil {
+		return
+	}
 
-		if err != nil {
-			a.logger.Error(err)
-		} else {
-			metric.Set(sample.Value)
-		}
+	labelValues := a.tagsToLabelValues(gauge.labelNames, sample.Tags, res.labels)
+	if !a.admit(res.name, labelValues) {
+		return
 	}
-}
 
-var syntheticBuckets = []float64{
-	5, 10, 50, 100, 250, 500, 750, 1000, 2000, 5000, 10000, 20000, 30000,
+	metric, err := gauge.gaugeVec.GetMetricWithLabelValues(labelValues...)
+	if err != nil {
+		a.logger.Error(err)
+	} else {
+		metric.Set(sample.Value)
+	}
 }
+
 var defaultBuckets = []float64{0}
 
-func (a *PrometheusAdapter) handleRate(sample *metrics.Sample) {
-	buckets := defaultBuckets
-	if sample.Metric.Name == "coolname" {
-		buckets = syntheticBuckets
+// defaultTrendBuckets are duration (ms) buckets used for a Trend histogram
+// when no mapping rule supplies its own.
+var defaultTrendBuckets = []float64{
+	5, 10, 25, 50, 75, 100, 250, 500, 750, 1000, 2500, 5000, 7500, 10000, 30000, 60000,
+}
+
+func (a *PrometheusAdapter) exposeHistogram(sample *metrics.Sample, res resolution) {
+	helpSuffix := "k6 rate"
+	if sample.Metric.Type == metrics.Trend {
+		helpSuffix = "k6 trend"
 	}
 
-	if histogram := a.getHistogram(sample.Metric.Name, "k6 rate", buckets, sample.Tags); histogram != nil {
-		labelValues := a.tagsToLabelValues(histogram.labelNames, sample.Tags)
-		metric, err := histogram.histogramVec.GetMetricWithLabelValues(labelValues...)
+	histogram := a.getHistogram(res.name, helpSuffix, res.help, res.buckets, res.native, sample.Tags, res.labels)
+	if histogram == nil {
+		return
+	}
 
-		if err != nil {
-			a.logger.Error(err)
-		} else {
-			metric.Observe(sample.Value)
-		}
+	labelValues := a.tagsToLabelValues(histogram.labelNames, sample.Tags, res.labels)
+	if !a.admit(res.name, labelValues) {
+		return
+	}
+
+	metric, err := histogram.histogramVec.GetMetricWithLabelValues(labelValues...)
+	if err != nil {
+		a.logger.Error(err)
+	} else {
+		metric.Observe(sample.Value)
 	}
 }
 
-func (a *PrometheusAdapter) handleTrend(sample *metrics.Sample) {
-	if summary := a.getSummary(sample.Metric.Name, "k6 trend", sample.Tags); summary != nil {
-		labelValues := a.tagsToLabelValues(summary.labelNames, sample.Tags)
+func (a *PrometheusAdapter) exposeSummary(sample *metrics.Sample, res resolution) {
+	summary := a.getSummary(res.name, "k6 trend", res.help, res.quantiles, sample.Tags, res.labels)
+	if summary == nil {
+		return
+	}
 
-		metric, err := summary.summaryVec.GetMetricWithLabelValues(labelValues...)
-		if err != nil {
-			a.logger.Error(err)
-		} else {
-			metric.Observe(sample.Value)
-		}
+	labelValues := a.tagsToLabelValues(summary.labelNames, sample.Tags, res.labels)
+	if !a.admit(res.name, labelValues) {
+		return
 	}
 
-	if gauge := a.getGauge(sample.Metric.Name+"_current", "k6 trend (current)", sample.Tags); gauge != nil {
-		labelValues := a.tagsToLabelValues(gauge.labelNames, sample.Tags)
+	metric, err := summary.summaryVec.GetMetricWithLabelValues(labelValues...)
+	if err != nil {
+		a.logger.Error(err)
+	} else {
+		metric.Observe(sample.Value)
+	}
+}
 
-		metric, err := gauge.gaugeVec.GetMetricWithLabelValues(labelValues...)
-		if err != nil {
-			a.logger.Error(err)
-		} else {
-			metric.Set(sample.Value)
-		}
+func (a *PrometheusAdapter) exposeCurrent(sample *metrics.Sample, res resolution) {
+	name := res.name + "_current"
+
+	gauge := a.getGauge(name, "k6 trend (current)", "", sample.Tags, res.labels)
+	if gauge == nil {
+		return
+	}
+
+	labelValues := a.tagsToLabelValues(gauge.labelNames, sample.Tags, res.labels)
+	if !a.admit(name, labelValues) {
+		return
+	}
+
+	metric, err := gauge.gaugeVec.GetMetricWithLabelValues(labelValues...)
+	if err != nil {
+		a.logger.Error(err)
+	} else {
+		metric.Set(sample.Value)
 	}
 }
 
-func (a *PrometheusAdapter) getCounter(name string, helpSuffix string, tags *metrics.TagSet) *counterWithLabels { // nolint:dupl
+func (a *PrometheusAdapter) getCounter( // nolint:dupl
+	name, helpSuffix, help string, tags *metrics.TagSet, labels LabelRules,
+) *counterWithLabels {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	var counter *counterWithLabels
 
 	if col, ok := a.metrics[name]; ok {
@@ -202,13 +634,13 @@ func (a *PrometheusAdapter) getCounter(name string, helpSuffix string, tags *met
 	}
 
 	if counter == nil {
-		labelNames := a.tagsToLabelNames(tags)
+		labelNames := a.tagsToLabelNames(tags, labels)
 		counter = &counterWithLabels{
 			counterVec: prometheus.NewCounterVec(prometheus.CounterOpts{ // nolint:exhaustruct
 				Namespace: a.Namespace,
 				Subsystem: a.Subsystem,
 				Name:      name,
-				Help:      helpFor(name, helpSuffix),
+				Help:      helpFor(name, helpSuffix, help),
 			}, labelNames),
 			labelNames: labelNames,
 		}
@@ -225,7 +657,12 @@ func (a *PrometheusAdapter) getCounter(name string, helpSuffix string, tags *met
 	return counter
 }
 
-func (a *PrometheusAdapter) getGauge(name string, helpSuffix string, tags *metrics.TagSet) *gaugeWithLabels { // nolint:dupl
+func (a *PrometheusAdapter) getGauge( // nolint:dupl
+	name, helpSuffix, help string, tags *metrics.TagSet, labels LabelRules,
+) *gaugeWithLabels {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	var gauge *gaugeWithLabels
 
 	if gau, ok := a.metrics[name]; ok {
@@ -237,13 +674,13 @@ func (a *PrometheusAdapter) getGauge(name string, helpSuffix string, tags *metri
 	}
 
 	if gauge == nil {
-		labelNames := a.tagsToLabelNames(tags)
+		labelNames := a.tagsToLabelNames(tags, labels)
 		gauge = &gaugeWithLabels{
 			gaugeVec: prometheus.NewGaugeVec(prometheus.GaugeOpts{ // nolint:exhaustruct
 				Namespace: a.Namespace,
 				Subsystem: a.Subsystem,
 				Name:      name,
-				Help:      helpFor(name, helpSuffix),
+				Help:      helpFor(name, helpSuffix, help),
 			}, labelNames),
 			labelNames: labelNames,
 		}
@@ -260,7 +697,14 @@ func (a *PrometheusAdapter) getGauge(name string, helpSuffix string, tags *metri
 	return gauge
 }
 
-func (a *PrometheusAdapter) getSummary(name string, helpSuffix string, tags *metrics.TagSet) *summaryWithLabels {
+var defaultQuantiles = map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.95: 0.001, 1: 0} // nolint:gomnd
+
+func (a *PrometheusAdapter) getSummary(
+	name, helpSuffix, help string, quantiles map[float64]float64, tags *metrics.TagSet, labels LabelRules,
+) *summaryWithLabels {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	var summary *summaryWithLabels
 
 	if sum, ok := a.metrics[name]; ok {
@@ -272,14 +716,19 @@ func (a *PrometheusAdapter) getSummary(name string, helpSuffix string, tags *met
 	}
 
 	if summary == nil {
-		labelNames := a.tagsToLabelNames(tags)
+		objectives := quantiles
+		if objectives == nil {
+			objectives = defaultQuantiles
+		}
+
+		labelNames := a.tagsToLabelNames(tags, labels)
 		summary = &summaryWithLabels{
 			summaryVec: prometheus.NewSummaryVec(prometheus.SummaryOpts{ // nolint:exhaustruct
 				Namespace:  a.Namespace,
 				Subsystem:  a.Subsystem,
 				Name:       name,
-				Help:       helpFor(name, helpSuffix),
-				Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.95: 0.001, 1: 0}, // nolint:gomnd
+				Help:       helpFor(name, helpSuffix, help),
+				Objectives: objectives,
 			}, labelNames),
 			labelNames: labelNames,
 		}
@@ -296,7 +745,12 @@ func (a *PrometheusAdapter) getSummary(name string, helpSuffix string, tags *met
 	return summary
 }
 
-func (a *PrometheusAdapter) getHistogram(name string, helpSuffix string, buckets []float64, tags *metrics.TagSet) *histogramWithLabels {
+func (a *PrometheusAdapter) getHistogram( // nolint:cyclop
+	name, helpSuffix, help string, buckets []float64, native bool, tags *metrics.TagSet, labels LabelRules,
+) *histogramWithLabels {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	var histogram *histogramWithLabels
 
 	if his, ok := a.metrics[name]; ok {
@@ -308,16 +762,23 @@ func (a *PrometheusAdapter) getHistogram(name string, helpSuffix string, buckets
 	}
 
 	if histogram == nil {
-		labelNames := a.tagsToLabelNames(tags)
+		opts := prometheus.HistogramOpts{ // nolint:exhaustruct
+			Namespace: a.Namespace,
+			Subsystem: a.Subsystem,
+			Name:      name,
+			Help:      helpFor(name, helpSuffix, help),
+			Buckets:   buckets,
+		}
+
+		if native {
+			opts.NativeHistogramBucketFactor = a.nativeBucketFactor
+			opts.NativeHistogramMaxBucketNumber = a.nativeMaxBuckets
+		}
+
+		labelNames := a.tagsToLabelNames(tags, labels)
 		histogram = &histogramWithLabels{
-			histogramVec: prometheus.NewHistogramVec(prometheus.HistogramOpts{ // nolint:exhaustruct
-				Namespace: a.Namespace,
-				Subsystem: a.Subsystem,
-				Name:      name,
-				Help:      helpFor(name, helpSuffix),
-				Buckets:   buckets,
-			}, labelNames),
-			labelNames: labelNames,
+			histogramVec: prometheus.NewHistogramVec(opts, labelNames),
+			labelNames:   labelNames,
 		}
 
 		if err := a.registry.Register(histogram.histogramVec); err != nil {
@@ -332,7 +793,11 @@ func (a *PrometheusAdapter) getHistogram(name string, helpSuffix string, buckets
 	return histogram
 }
 
-func helpFor(name string, helpSuffix string) string {
+func helpFor(name, helpSuffix, custom string) string {
+	if custom != "" {
+		return custom
+	}
+
 	if h, ok := builtinMetrics[name]; ok {
 		return h
 	}
@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText: 2021 - 2023 Iván Szkiba
+//
+// SPDX-License-Identifier: MIT
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus/hooks/test"
+	"go.k6.io/k6/metrics"
+)
+
+func newTestAdapter(ttl time.Duration) *PrometheusAdapter {
+	logger, _ := test.NewNullLogger()
+
+	return NewPrometheusAdapter(prometheus.NewRegistry(), logger, "", "", ttl)
+}
+
+func newTestSample(reg *metrics.Registry, name string, typ metrics.MetricType, tags *metrics.TagSet, value float64) metrics.Sample {
+	metric := reg.MustNewMetric(name, typ)
+
+	return metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tags},
+		Time:       time.Now(),
+		Value:      value,
+	}
+}
+
+func TestSweepExpiresIdleSeriesAndUnregistersEmptyVec(t *testing.T) {
+	a := newTestAdapter(time.Hour) // large ttl: the background sweeper must not fire during the test
+	defer a.Close()
+
+	reg := metrics.NewRegistry()
+	tags := reg.RootTagSet().With("region", "eu")
+	sample := newTestSample(reg, "my_counter", metrics.Counter, tags, 1)
+
+	a.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{sample}})
+
+	if _, ok := a.metrics["my_counter"]; !ok {
+		t.Fatal("expected my_counter to be registered")
+	}
+
+	series, ok := a.seriesByMetric["my_counter"]
+	if !ok || len(series) != 1 {
+		t.Fatalf("expected exactly one series for my_counter, got %v", series)
+	}
+
+	for _, info := range series {
+		info.lastSeen = time.Now().Add(-2 * time.Hour)
+	}
+
+	a.sweep(time.Now())
+
+	if _, ok := a.metrics["my_counter"]; ok {
+		t.Fatal("expected my_counter to be unregistered after its only series expired")
+	}
+
+	if _, ok := a.seriesByMetric["my_counter"]; ok {
+		t.Fatal("expected seriesByMetric entry for my_counter to be removed")
+	}
+}
+
+func TestSweepKeepsVecWithLiveSeries(t *testing.T) {
+	a := newTestAdapter(time.Hour)
+	defer a.Close()
+
+	reg := metrics.NewRegistry()
+	tags := reg.RootTagSet().With("region", "eu")
+	sample := newTestSample(reg, "my_counter", metrics.Counter, tags, 1)
+
+	a.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{sample}})
+	a.sweep(time.Now())
+
+	if _, ok := a.metrics["my_counter"]; !ok {
+		t.Fatal("expected my_counter to survive a sweep while its series is still fresh")
+	}
+}
+
+func TestAdmitEnforcesMaxSeriesPerMetric(t *testing.T) {
+	a := newTestAdapter(0)
+	defer a.Close()
+
+	a.SetCardinalityLimits(2, 0)
+
+	if !a.admit("m", []string{"a"}) {
+		t.Fatal("expected first series to be admitted")
+	}
+
+	if !a.admit("m", []string{"b"}) {
+		t.Fatal("expected second series to be admitted")
+	}
+
+	if a.admit("m", []string{"c"}) {
+		t.Fatal("expected third series to be rejected once max_series_per_metric is reached")
+	}
+
+	// Re-observing an already-admitted series must still succeed.
+	if !a.admit("m", []string{"a"}) {
+		t.Fatal("expected an existing series to always be admitted")
+	}
+
+	if got := testutil.ToFloat64(a.droppedSeries.WithLabelValues("m")); got != 1 {
+		t.Fatalf("expected xk6_prometheus_dropped_series_total{metric=\"m\"} == 1, got %v", got)
+	}
+}
+
+func TestAdmitEnforcesMaxTotalSeriesAcrossMetrics(t *testing.T) {
+	a := newTestAdapter(0)
+	defer a.Close()
+
+	a.SetCardinalityLimits(0, 2)
+
+	if !a.admit("m1", []string{"a"}) {
+		t.Fatal("expected first series to be admitted")
+	}
+
+	if !a.admit("m2", []string{"b"}) {
+		t.Fatal("expected second series to be admitted")
+	}
+
+	if a.admit("m3", []string{"c"}) {
+		t.Fatal("expected third series to be rejected once max_total_series is reached, even for a new metric")
+	}
+}
+
+func TestAdmitMaxSeriesPerMetricTakesPrecedenceOverTotal(t *testing.T) {
+	a := newTestAdapter(0)
+	defer a.Close()
+
+	a.SetCardinalityLimits(1, 10)
+
+	if !a.admit("m", []string{"a"}) {
+		t.Fatal("expected first series to be admitted")
+	}
+
+	if a.admit("m", []string{"b"}) {
+		t.Fatal("expected second series for the same metric to be rejected by max_series_per_metric, though max_total_series allows it")
+	}
+}
+
+func TestResolveMappingOverridesNameBucketsAndType(t *testing.T) {
+	a := newTestAdapter(0)
+	defer a.Close()
+
+	a.SetMapping(&MappingConfig{
+		Rules: []MappingRule{
+			{
+				Match:           "http_req_duration",
+				Name:            "request_duration",
+				MatchMetricType: "histogram",
+				Buckets:         []float64{1, 2, 3},
+			},
+		},
+	})
+
+	reg := metrics.NewRegistry()
+	tags := reg.RootTagSet()
+	metric := reg.MustNewMetric("http_req_duration", metrics.Trend)
+	sample := &metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tags}, Time: time.Now(), Value: 1}
+
+	res := a.resolve(sample, promSummary)
+
+	if res.name != "request_duration" {
+		t.Fatalf("expected mapping rule to rename the metric, got %q", res.name)
+	}
+
+	if res.promType != promHistogram {
+		t.Fatalf("expected match_metric_type to override the exposition type, got %v", res.promType)
+	}
+
+	if len(res.buckets) != 3 || res.buckets[2] != 3 {
+		t.Fatalf("expected mapping rule buckets to win, got %v", res.buckets)
+	}
+
+	if res.native {
+		t.Fatal("expected an explicit match_metric_type override to force a classic histogram")
+	}
+}
+
+func TestResolveTrendHistogramGetsDurationBuckets(t *testing.T) {
+	a := newTestAdapter(0)
+	defer a.Close()
+
+	a.SetTrendMode(TrendHistogram, 0, 0)
+
+	reg := metrics.NewRegistry()
+	metric := reg.MustNewMetric("http_req_duration", metrics.Trend)
+	sample := &metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: metric, Tags: reg.RootTagSet()}, Time: time.Now(), Value: 1}
+
+	res := a.resolve(sample, promSummary)
+
+	if res.promType != promHistogram {
+		t.Fatalf("expected TrendHistogram mode to expose a histogram, got %v", res.promType)
+	}
+
+	if len(res.buckets) != len(defaultTrendBuckets) || res.buckets[0] != defaultTrendBuckets[0] {
+		t.Fatalf("expected Trend to default to defaultTrendBuckets, got %v", res.buckets)
+	}
+}
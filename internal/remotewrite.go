@@ -0,0 +1,291 @@
+// SPDX-FileCopyrightText: 2021 - 2023 Iván Szkiba
+//
+// SPDX-License-Identifier: MIT
+
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/sirupsen/logrus"
+)
+
+// RemoteWriter periodically gathers the registry and pushes it to a
+// Prometheus remote_write endpoint.
+type RemoteWriter struct {
+	URL         string
+	Interval    time.Duration
+	Tenant      string
+	BearerToken string
+	Username    string
+	Password    string
+	Headers     map[string]string
+
+	registry *prometheus.Registry
+	logger   logrus.FieldLogger
+	client   *http.Client
+
+	done     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+
+	warnedLossy map[string]bool
+}
+
+// NewRemoteWriter creates a writer that gathers registry and pushes to url
+// every interval, until Stop is called.
+func NewRemoteWriter(registry *prometheus.Registry, logger logrus.FieldLogger, url string, interval time.Duration) *RemoteWriter {
+	const pushTimeout = 10 * time.Second
+
+	return &RemoteWriter{
+		URL:         url,
+		Interval:    interval,
+		Tenant:      "",
+		BearerToken: "",
+		Username:    "",
+		Password:    "",
+		Headers:     make(map[string]string),
+		registry:    registry,
+		logger:      logger,
+		client:      &http.Client{Timeout: pushTimeout}, // nolint:exhaustruct
+		done:        make(chan struct{}),
+		wg:          sync.WaitGroup{}, // nolint:exhaustruct
+		warnedLossy: make(map[string]bool),
+	}
+}
+
+// SetBasicAuth configures HTTP basic auth for the remote_write requests.
+func (w *RemoteWriter) SetBasicAuth(username, password string) {
+	w.Username = username
+	w.Password = password
+}
+
+// SetHeader sets a custom header (e.g. a tenant header other than
+// X-Scope-OrgID) sent with every remote_write request.
+func (w *RemoteWriter) SetHeader(key, value string) {
+	w.Headers[key] = value
+}
+
+// Start begins the periodic push loop in the background.
+func (w *RemoteWriter) Start() {
+	w.wg.Add(1)
+
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := w.push(); err != nil {
+					w.logger.Error(err)
+				}
+			case <-w.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the periodic push loop and performs one final push.
+func (w *RemoteWriter) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.done)
+		w.wg.Wait()
+
+		if err := w.push(); err != nil {
+			w.logger.Error(err)
+		}
+	})
+}
+
+func (w *RemoteWriter) push() error {
+	families, err := w.registry.Gather()
+	if err != nil {
+		return fmt.Errorf("gather metrics: %w", err)
+	}
+
+	series := w.familiesToTimeSeries(families, time.Now())
+	if len(series) == 0 {
+		return nil
+	}
+
+	return w.send(series)
+}
+
+func (w *RemoteWriter) send(series []prompb.TimeSeries) error {
+	data, err := proto.Marshal(&prompb.WriteRequest{ // nolint:exhaustruct
+		Timeseries: series,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal write request: %w", err)
+	}
+
+	compressed := snappy.Encode(nil, data)
+
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(compressed))
+	if err != nil {
+		return fmt.Errorf("build remote_write request: %w", err)
+	}
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	if w.Tenant != "" {
+		req.Header.Set("X-Scope-OrgID", w.Tenant)
+	}
+
+	if w.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+w.BearerToken)
+	}
+
+	if w.Username != "" {
+		req.SetBasicAuth(w.Username, w.Password)
+	}
+
+	for key, value := range w.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push to %s: %w", w.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 { // nolint:gomnd
+		return fmt.Errorf("remote_write to %s returned %s", w.URL, resp.Status)
+	}
+
+	return nil
+}
+
+func (w *RemoteWriter) familiesToTimeSeries(families []*dto.MetricFamily, now time.Time) []prompb.TimeSeries {
+	ts := now.UnixMilli()
+
+	var result []prompb.TimeSeries
+
+	for _, family := range families {
+		name := family.GetName()
+
+		for _, metric := range family.GetMetric() {
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				result = append(result, sampleSeries(name, metric.GetCounter().GetValue(), ts, metric))
+			case dto.MetricType_GAUGE:
+				result = append(result, sampleSeries(name, metric.GetGauge().GetValue(), ts, metric))
+			case dto.MetricType_SUMMARY:
+				result = append(result, summarySeries(name, metric, ts)...)
+			case dto.MetricType_HISTOGRAM:
+				result = append(result, w.histogramSeries(name, metric, ts)...)
+			case dto.MetricType_UNTYPED, dto.MetricType_GAUGE_HISTOGRAM:
+				// Not emitted by PrometheusAdapter; nothing to convert.
+			}
+		}
+	}
+
+	return result
+}
+
+func summarySeries(name string, metric *dto.Metric, ts int64) []prompb.TimeSeries {
+	summary := metric.GetSummary()
+
+	result := []prompb.TimeSeries{
+		sampleSeries(name+"_sum", summary.GetSampleSum(), ts, metric),
+		sampleSeries(name+"_count", float64(summary.GetSampleCount()), ts, metric),
+	}
+
+	for _, q := range summary.GetQuantile() {
+		result = append(result, sampleSeries(name, q.GetValue(), ts, metric,
+			prompb.Label{Name: "quantile", Value: formatFloat(q.GetQuantile())}))
+	}
+
+	return result
+}
+
+func (w *RemoteWriter) histogramSeries(name string, metric *dto.Metric, ts int64) []prompb.TimeSeries {
+	histogram := metric.GetHistogram()
+
+	result := []prompb.TimeSeries{
+		sampleSeries(name+"_sum", histogram.GetSampleSum(), ts, metric),
+		sampleSeries(name+"_count", float64(histogram.GetSampleCount()), ts, metric),
+	}
+
+	buckets := histogram.GetBucket()
+	if len(buckets) == 0 && histogram.GetSampleCount() > 0 {
+		w.warnLossyHistogram(name)
+	}
+
+	infSeen := false
+
+	for _, bucket := range buckets {
+		result = append(result, sampleSeries(name+"_bucket", float64(bucket.GetCumulativeCount()), ts, metric,
+			prompb.Label{Name: "le", Value: formatFloat(bucket.GetUpperBound())}))
+
+		if math.IsInf(bucket.GetUpperBound(), 1) {
+			infSeen = true
+		}
+	}
+
+	// client_golang strips the +Inf bucket from GetBucket() since it's
+	// implicit; synthesize it back, the same way expfmt's text encoder does.
+	if !infSeen {
+		result = append(result, sampleSeries(name+"_bucket", float64(histogram.GetSampleCount()), ts, metric,
+			prompb.Label{Name: "le", Value: "+Inf"}))
+	}
+
+	return result
+}
+
+// warnLossyHistogram logs once per metric name that only _sum/_count and the
+// +Inf bucket could be pushed, since the histogram has no classic buckets to
+// expand.
+func (w *RemoteWriter) warnLossyHistogram(name string) {
+	if w.warnedLossy[name] {
+		return
+	}
+
+	w.warnedLossy[name] = true
+	w.logger.Warnf("remote_write: %q has no classic buckets, pushing _sum/_count and the +Inf bucket only; quantiles will be lost", name)
+}
+
+func sampleSeries(name string, value float64, ts int64, metric *dto.Metric, extra ...prompb.Label) prompb.TimeSeries {
+	labels := make([]prompb.Label, 0, len(metric.GetLabel())+1+len(extra))
+	labels = append(labels, prompb.Label{Name: "__name__", Value: name})
+
+	for _, lp := range metric.GetLabel() {
+		labels = append(labels, prompb.Label{Name: lp.GetName(), Value: lp.GetValue()})
+	}
+
+	labels = append(labels, extra...)
+
+	return prompb.TimeSeries{ // nolint:exhaustruct
+		Labels:  labels,
+		Samples: []prompb.Sample{{Value: value, Timestamp: ts}},
+	}
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
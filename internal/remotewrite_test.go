@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2021 - 2023 Iván Szkiba
+//
+// SPDX-License-Identifier: MIT
+
+package internal
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func f64(v float64) *float64 { return &v }
+func u64(v uint64) *uint64   { return &v }
+func str(v string) *string   { return &v }
+
+func newTestWriter() *RemoteWriter {
+	logger, _ := test.NewNullLogger()
+
+	return NewRemoteWriter(prometheus.NewRegistry(), logger, "http://example.invalid/api/v1/write", time.Minute)
+}
+
+func seriesByName(series []prompb.TimeSeries, name string) []prompb.TimeSeries {
+	var matched []prompb.TimeSeries
+
+	for _, s := range series {
+		for _, l := range s.Labels {
+			if l.Name == "__name__" && l.Value == name {
+				matched = append(matched, s)
+			}
+		}
+	}
+
+	return matched
+}
+
+func labelValue(s prompb.TimeSeries, name string) (string, bool) {
+	for _, l := range s.Labels {
+		if l.Name == name {
+			return l.Value, true
+		}
+	}
+
+	return "", false
+}
+
+func TestHistogramSeriesAddsSyntheticInfBucket(t *testing.T) {
+	w := newTestWriter()
+
+	metric := &dto.Metric{ // nolint:exhaustruct
+		Label: []*dto.LabelPair{{Name: str("env"), Value: str("prod")}},
+		Histogram: &dto.Histogram{ // nolint:exhaustruct
+			SampleCount: u64(3),
+			SampleSum:   f64(12.5),
+			Bucket: []*dto.Bucket{
+				{CumulativeCount: u64(1), UpperBound: f64(1)}, // nolint:exhaustruct
+				{CumulativeCount: u64(2), UpperBound: f64(5)}, // nolint:exhaustruct
+			},
+		},
+	}
+
+	series := w.histogramSeries("req_duration", metric, 1000)
+
+	sum := seriesByName(series, "req_duration_sum")
+	if len(sum) != 1 || sum[0].Samples[0].Value != 12.5 {
+		t.Fatalf("expected req_duration_sum == 12.5, got %v", sum)
+	}
+
+	count := seriesByName(series, "req_duration_count")
+	if len(count) != 1 || count[0].Samples[0].Value != 3 {
+		t.Fatalf("expected req_duration_count == 3, got %v", count)
+	}
+
+	buckets := seriesByName(series, "req_duration_bucket")
+	if len(buckets) != 3 {
+		t.Fatalf("expected 2 classic buckets plus a synthetic +Inf bucket, got %d: %v", len(buckets), buckets)
+	}
+
+	var infBucket *prompb.TimeSeries
+
+	for i := range buckets {
+		if le, _ := labelValue(buckets[i], "le"); le == "+Inf" {
+			infBucket = &buckets[i]
+		}
+	}
+
+	if infBucket == nil {
+		t.Fatal("expected a le=\"+Inf\" bucket series")
+	}
+
+	if infBucket.Samples[0].Value != 3 {
+		t.Fatalf("expected the +Inf bucket to carry the total sample count 3, got %v", infBucket.Samples[0].Value)
+	}
+
+	if env, ok := labelValue(*infBucket, "env"); !ok || env != "prod" {
+		t.Fatalf("expected the metric's own labels to propagate to the synthetic bucket, got %q", env)
+	}
+}
+
+func TestHistogramSeriesDoesNotDuplicateExplicitInfBucket(t *testing.T) {
+	w := newTestWriter()
+
+	metric := &dto.Metric{ // nolint:exhaustruct
+		Histogram: &dto.Histogram{ // nolint:exhaustruct
+			SampleCount: u64(1),
+			SampleSum:   f64(1),
+			Bucket: []*dto.Bucket{
+				{CumulativeCount: u64(1), UpperBound: f64(1)},           // nolint:exhaustruct
+				{CumulativeCount: u64(1), UpperBound: f64(math.Inf(1))}, // nolint:exhaustruct
+			},
+		},
+	}
+
+	series := w.histogramSeries("req_duration", metric, 1000)
+
+	buckets := seriesByName(series, "req_duration_bucket")
+	if len(buckets) != 2 {
+		t.Fatalf("expected no extra +Inf bucket when one is already present, got %d: %v", len(buckets), buckets)
+	}
+}
+
+func TestHistogramSeriesWarnsOnceWhenNoClassicBuckets(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	w := NewRemoteWriter(prometheus.NewRegistry(), logger, "http://example.invalid/api/v1/write", time.Minute)
+
+	metric := &dto.Metric{ // nolint:exhaustruct
+		Histogram: &dto.Histogram{SampleCount: u64(5), SampleSum: f64(1)}, // nolint:exhaustruct
+	}
+
+	w.histogramSeries("native_only", metric, 1000)
+	w.histogramSeries("native_only", metric, 1000)
+
+	if got := len(hook.Entries); got != 1 {
+		t.Fatalf("expected the lossy-histogram warning to be logged once, got %d entries", got)
+	}
+}
+
+func TestSummarySeriesIncludesQuantiles(t *testing.T) {
+	metric := &dto.Metric{ // nolint:exhaustruct
+		Summary: &dto.Summary{ // nolint:exhaustruct
+			SampleCount: u64(10),
+			SampleSum:   f64(42),
+			Quantile: []*dto.Quantile{
+				{Quantile: f64(0.5), Value: f64(1.5)}, // nolint:exhaustruct
+				{Quantile: f64(0.9), Value: f64(4)},   // nolint:exhaustruct
+			},
+		},
+	}
+
+	series := summarySeries("req_duration", metric, 1000)
+
+	quantiles := seriesByName(series, "req_duration")
+	if len(quantiles) != 2 {
+		t.Fatalf("expected 2 quantile series, got %d: %v", len(quantiles), quantiles)
+	}
+
+	for _, q := range quantiles {
+		le, ok := labelValue(q, "quantile")
+		if !ok {
+			t.Fatalf("expected a quantile label on %v", q)
+		}
+
+		if le == "0.5" && q.Samples[0].Value != 1.5 {
+			t.Fatalf("expected quantile 0.5 to be 1.5, got %v", q.Samples[0].Value)
+		}
+	}
+
+	if sum := seriesByName(series, "req_duration_sum"); len(sum) != 1 || sum[0].Samples[0].Value != 42 {
+		t.Fatalf("expected req_duration_sum == 42, got %v", sum)
+	}
+
+	if count := seriesByName(series, "req_duration_count"); len(count) != 1 || count[0].Samples[0].Value != 10 {
+		t.Fatalf("expected req_duration_count == 10, got %v", count)
+	}
+}
+
+func TestFamiliesToTimeSeriesCounterAndGauge(t *testing.T) {
+	w := newTestWriter()
+
+	families := []*dto.MetricFamily{
+		{ // nolint:exhaustruct
+			Name: str("requests_total"),
+			Type: dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{
+				{ // nolint:exhaustruct
+					Label:   []*dto.LabelPair{{Name: str("env"), Value: str("prod")}}, // nolint:exhaustruct
+					Counter: &dto.Counter{Value: f64(5)},                              // nolint:exhaustruct
+				},
+			},
+		},
+		{ // nolint:exhaustruct
+			Name: str("vus"),
+			Type: dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{
+				{Gauge: &dto.Gauge{Value: f64(7)}}, // nolint:exhaustruct
+			},
+		},
+	}
+
+	series := w.familiesToTimeSeries(families, time.Unix(0, 0))
+
+	counters := seriesByName(series, "requests_total")
+	if len(counters) != 1 || counters[0].Samples[0].Value != 5 {
+		t.Fatalf("expected requests_total == 5, got %v", counters)
+	}
+
+	if env, ok := labelValue(counters[0], "env"); !ok || env != "prod" {
+		t.Fatalf("expected the counter's labels to propagate, got %q", env)
+	}
+
+	gauges := seriesByName(series, "vus")
+	if len(gauges) != 1 || gauges[0].Samples[0].Value != 7 {
+		t.Fatalf("expected vus == 7, got %v", gauges)
+	}
+}